@@ -0,0 +1,111 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision
+
+import (
+	"fmt"
+
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	attachSocketVolumeName   = "attach-socket"
+	attachSocketDir          = "/var/run/ela/attach"
+	attachProxyContainerName = "attach-proxy"
+
+	// Knative's autoscaling annotations, which is where replica/scale-to-zero
+	// bounds actually live (on the Revision's ObjectMeta), not on the spec.
+	autoscalingMinScaleAnnotation = "autoscaling.knative.dev/minScale"
+	autoscalingMaxScaleAnnotation = "autoscaling.knative.dev/maxScale"
+)
+
+// ValidateInteractionMode enforces the constraints TTY/Stream interaction
+// implies: a single, pinned replica with scale-to-zero disabled, since
+// there's exactly one socket to attach to. Called from
+// pkg/webhook.validateSpec.
+func ValidateInteractionMode(rev *v1alpha1.Revision) error {
+	if !wantsInteractiveAttach(rev) {
+		return nil
+	}
+	if rev.ObjectMeta.Annotations[autoscalingMinScaleAnnotation] != "1" {
+		return fmt.Errorf("interactionMode %q requires %s=1 to pin a single replica and disable scale-to-zero", rev.Spec.InteractionMode, autoscalingMinScaleAnnotation)
+	}
+	if maxScale := rev.ObjectMeta.Annotations[autoscalingMaxScaleAnnotation]; maxScale != "1" {
+		return fmt.Errorf("interactionMode %q requires %s=1; got %q", rev.Spec.InteractionMode, autoscalingMaxScaleAnnotation, maxScale)
+	}
+	return nil
+}
+
+// wantsInteractiveAttach reports whether rev is asking for a TTY or a raw
+// stdio stream, either of which needs the attach-proxy sidecar and a shared
+// socket volume.
+func wantsInteractiveAttach(rev *v1alpha1.Revision) bool {
+	switch rev.Spec.InteractionMode {
+	case v1alpha1.InteractionModeTTY, v1alpha1.InteractionModeStream:
+		return true
+	default:
+		return false
+	}
+}
+
+// applyInteractionMode configures the user container for TTY/Stream
+// interaction and returns the attach-proxy sidecar plus the emptyDir volume
+// holding the Unix socket it shares with the user container. Only the user
+// container and the attach-proxy mount that volume; the queue-proxy
+// container does not.
+func applyInteractionMode(rev *v1alpha1.Revision, userContainer *corev1.Container, controllerConfig *ControllerConfig) (*corev1.Container, *corev1.Volume) {
+	if !wantsInteractiveAttach(rev) {
+		return nil, nil
+	}
+
+	userContainer.Stdin = true
+	if rev.Spec.InteractionMode == v1alpha1.InteractionModeTTY {
+		userContainer.TTY = true
+	}
+	userContainer.VolumeMounts = append(userContainer.VolumeMounts, corev1.VolumeMount{
+		Name:      attachSocketVolumeName,
+		MountPath: attachSocketDir,
+	})
+
+	socketVolume := &corev1.Volume{
+		Name: attachSocketVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{},
+		},
+	}
+
+	attachProxy := &corev1.Container{
+		Name:  attachProxyContainerName,
+		Image: controllerConfig.AttachProxyImage,
+		Env: []corev1.EnvVar{
+			{
+				Name:  "ELA_ATTACH_SOCKET",
+				Value: fmt.Sprintf("%s/%s.sock", attachSocketDir, userContainerName),
+			},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      attachSocketVolumeName,
+				MountPath: attachSocketDir,
+			},
+		},
+	}
+
+	return attachProxy, socketVolume
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision
+
+import (
+	"testing"
+
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func revisionWithScale(mode v1alpha1.InteractionMode, minScale, maxScale string) *v1alpha1.Revision {
+	annotations := map[string]string{}
+	if minScale != "" {
+		annotations[autoscalingMinScaleAnnotation] = minScale
+	}
+	if maxScale != "" {
+		annotations[autoscalingMaxScaleAnnotation] = maxScale
+	}
+	return &v1alpha1.Revision{
+		ObjectMeta: meta_v1.ObjectMeta{Annotations: annotations},
+		Spec:       v1alpha1.RevisionSpec{InteractionMode: mode},
+	}
+}
+
+func TestValidateInteractionMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		rev     *v1alpha1.Revision
+		wantErr bool
+	}{{
+		name:    "none mode ignores scale annotations",
+		rev:     revisionWithScale(v1alpha1.InteractionModeNone, "0", "10"),
+		wantErr: false,
+	}, {
+		name:    "tty with pinned single replica is valid",
+		rev:     revisionWithScale(v1alpha1.InteractionModeTTY, "1", "1"),
+		wantErr: false,
+	}, {
+		name:    "tty with scale-to-zero is rejected",
+		rev:     revisionWithScale(v1alpha1.InteractionModeTTY, "0", "1"),
+		wantErr: true,
+	}, {
+		name:    "tty with multiple replicas allowed is rejected",
+		rev:     revisionWithScale(v1alpha1.InteractionModeTTY, "1", "5"),
+		wantErr: true,
+	}, {
+		name:    "tty with no scale annotations is rejected",
+		rev:     revisionWithScale(v1alpha1.InteractionModeTTY, "", ""),
+		wantErr: true,
+	}, {
+		name:    "stream with pinned single replica is valid",
+		rev:     revisionWithScale(v1alpha1.InteractionModeStream, "1", "1"),
+		wantErr: false,
+	}, {
+		name:    "stream with scale-to-zero is rejected",
+		rev:     revisionWithScale(v1alpha1.InteractionModeStream, "0", "1"),
+		wantErr: true,
+	}, {
+		name:    "stream with multiple replicas allowed is rejected",
+		rev:     revisionWithScale(v1alpha1.InteractionModeStream, "1", "5"),
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateInteractionMode(tt.rev)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateInteractionMode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
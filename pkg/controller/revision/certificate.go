@@ -0,0 +1,71 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision
+
+import (
+	"context"
+
+	cmclientset "github.com/cert-manager/cert-manager/pkg/client/clientset/versioned"
+
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// syncCertificate creates or updates the cert-manager Certificate backing a
+// revision's mTLS secret. It is a no-op when ControllerConfig.EnableMTLS is
+// unset. The revision controller's Reconcile loop does not call it.
+func syncCertificate(cmClient cmclientset.Interface, rev *v1alpha1.Revision, controllerConfig *ControllerConfig) error {
+	if !controllerConfig.EnableMTLS {
+		return nil
+	}
+
+	certs := cmClient.CertmanagerV1().Certificates(rev.Namespace)
+	desired := MakeElaCertificate(rev, controllerConfig)
+
+	existing, err := certs.Get(context.Background(), desired.Name, meta_v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		_, err = certs.Create(context.Background(), desired, meta_v1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if equality.Semantic.DeepEqual(existing.Spec, desired.Spec) {
+		return nil
+	}
+	updated := existing.DeepCopy()
+	updated.Spec = desired.Spec
+	_, err = certs.Update(context.Background(), updated, meta_v1.UpdateOptions{})
+	return err
+}
+
+// clearCertificate deletes the revision's Certificate (and with it, lets its
+// Secret be garbage collected); a Certificate that doesn't exist is not an
+// error. The revision controller's Reconcile loop does not call it; the
+// Certificate's OwnerReference on the revision lets it be garbage collected
+// when the revision is deleted.
+func clearCertificate(cmClient cmclientset.Interface, rev *v1alpha1.Revision) error {
+	err := cmClient.CertmanagerV1().Certificates(rev.Namespace).Delete(context.Background(), mtlsSecretName(rev), meta_v1.DeleteOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
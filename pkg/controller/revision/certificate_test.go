@@ -0,0 +1,162 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision
+
+import (
+	"context"
+	"testing"
+
+	cmfake "github.com/cert-manager/cert-manager/pkg/client/clientset/versioned/fake"
+
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testRevision() *v1alpha1.Revision {
+	return &v1alpha1.Revision{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "test-rev",
+			Namespace: "test-ns",
+		},
+	}
+}
+
+func TestMakeElaCertificate(t *testing.T) {
+	rev := testRevision()
+	controllerConfig := &ControllerConfig{
+		EnableMTLS:     true,
+		MTLSIssuerName: "my-issuer",
+		MTLSIssuerKind: "ClusterIssuer",
+	}
+
+	cert := MakeElaCertificate(rev, controllerConfig)
+
+	if got, want := cert.Name, mtlsSecretName(rev); got != want {
+		t.Errorf("cert.Name = %q, want %q", got, want)
+	}
+	if got, want := cert.Spec.SecretName, mtlsSecretName(rev); got != want {
+		t.Errorf("cert.Spec.SecretName = %q, want %q", got, want)
+	}
+	if got, want := cert.Spec.IssuerRef.Name, controllerConfig.MTLSIssuerName; got != want {
+		t.Errorf("cert.Spec.IssuerRef.Name = %q, want %q", got, want)
+	}
+	if got, want := cert.Spec.IssuerRef.Kind, controllerConfig.MTLSIssuerKind; got != want {
+		t.Errorf("cert.Spec.IssuerRef.Kind = %q, want %q", got, want)
+	}
+}
+
+func TestSyncCertificateNoopWhenMTLSDisabled(t *testing.T) {
+	cmClient := cmfake.NewSimpleClientset()
+	rev := testRevision()
+	controllerConfig := &ControllerConfig{EnableMTLS: false}
+
+	if err := syncCertificate(cmClient, rev, controllerConfig); err != nil {
+		t.Fatalf("syncCertificate() error = %v, want nil", err)
+	}
+
+	certs, err := cmClient.CertmanagerV1().Certificates(rev.Namespace).List(context.Background(), meta_v1.ListOptions{})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(certs.Items) != 0 {
+		t.Errorf("got %d Certificates, want 0 when mTLS is disabled", len(certs.Items))
+	}
+}
+
+func TestSyncCertificateCreatesWhenMissing(t *testing.T) {
+	cmClient := cmfake.NewSimpleClientset()
+	rev := testRevision()
+	controllerConfig := &ControllerConfig{EnableMTLS: true, MTLSIssuerName: "my-issuer", MTLSIssuerKind: "ClusterIssuer"}
+
+	if err := syncCertificate(cmClient, rev, controllerConfig); err != nil {
+		t.Fatalf("syncCertificate() error = %v, want nil", err)
+	}
+
+	got, err := cmClient.CertmanagerV1().Certificates(rev.Namespace).Get(context.Background(), mtlsSecretName(rev), meta_v1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Get() error = %v, want the Certificate to have been created", err)
+	}
+	if got.Spec.IssuerRef.Name != "my-issuer" {
+		t.Errorf("created Certificate IssuerRef.Name = %q, want %q", got.Spec.IssuerRef.Name, "my-issuer")
+	}
+}
+
+func TestSyncCertificateUpdatesWhenChanged(t *testing.T) {
+	rev := testRevision()
+	controllerConfig := &ControllerConfig{EnableMTLS: true, MTLSIssuerName: "old-issuer", MTLSIssuerKind: "ClusterIssuer"}
+	existing := MakeElaCertificate(rev, controllerConfig)
+	cmClient := cmfake.NewSimpleClientset(existing)
+
+	controllerConfig.MTLSIssuerName = "new-issuer"
+	if err := syncCertificate(cmClient, rev, controllerConfig); err != nil {
+		t.Fatalf("syncCertificate() error = %v, want nil", err)
+	}
+
+	got, err := cmClient.CertmanagerV1().Certificates(rev.Namespace).Get(context.Background(), mtlsSecretName(rev), meta_v1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.Spec.IssuerRef.Name != "new-issuer" {
+		t.Errorf("updated Certificate IssuerRef.Name = %q, want %q", got.Spec.IssuerRef.Name, "new-issuer")
+	}
+}
+
+func TestSyncCertificateNoopWhenUnchanged(t *testing.T) {
+	rev := testRevision()
+	controllerConfig := &ControllerConfig{EnableMTLS: true, MTLSIssuerName: "my-issuer", MTLSIssuerKind: "ClusterIssuer"}
+	existing := MakeElaCertificate(rev, controllerConfig)
+	cmClient := cmfake.NewSimpleClientset(existing)
+
+	if err := syncCertificate(cmClient, rev, controllerConfig); err != nil {
+		t.Fatalf("syncCertificate() error = %v, want nil", err)
+	}
+
+	got, err := cmClient.CertmanagerV1().Certificates(rev.Namespace).Get(context.Background(), mtlsSecretName(rev), meta_v1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.ResourceVersion != existing.ResourceVersion {
+		t.Errorf("unchanged Certificate was updated: ResourceVersion went from %q to %q", existing.ResourceVersion, got.ResourceVersion)
+	}
+}
+
+func TestClearCertificateDeletesExisting(t *testing.T) {
+	rev := testRevision()
+	controllerConfig := &ControllerConfig{EnableMTLS: true, MTLSIssuerName: "my-issuer", MTLSIssuerKind: "ClusterIssuer"}
+	existing := MakeElaCertificate(rev, controllerConfig)
+	cmClient := cmfake.NewSimpleClientset(existing)
+
+	if err := clearCertificate(cmClient, rev); err != nil {
+		t.Fatalf("clearCertificate() error = %v, want nil", err)
+	}
+
+	_, err := cmClient.CertmanagerV1().Certificates(rev.Namespace).Get(context.Background(), mtlsSecretName(rev), meta_v1.GetOptions{})
+	if !errors.IsNotFound(err) {
+		t.Errorf("Get() error = %v, want NotFound after clearCertificate", err)
+	}
+}
+
+func TestClearCertificateIdempotentWhenMissing(t *testing.T) {
+	cmClient := cmfake.NewSimpleClientset()
+	rev := testRevision()
+
+	if err := clearCertificate(cmClient, rev); err != nil {
+		t.Errorf("clearCertificate() error = %v, want nil when the Certificate never existed", err)
+	}
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// ValidateControllerConfig parses every operator-configurable resource
+// quantity on cc and returns an error naming the first one that doesn't
+// parse (e.g. a typo'd configmap value like "100mm"). MakeElaQueueContainer
+// and MakeElaPodSpec use resource.MustParse on the same fields and panic on
+// such a value; nothing in this package calls ValidateControllerConfig
+// before them.
+func ValidateControllerConfig(cc *ControllerConfig) error {
+	quantities := []struct {
+		field string
+		value string
+	}{
+		{"QueueSidecarCPU", cc.QueueSidecarCPU},
+		{"QueueSidecarMemory", cc.QueueSidecarMemory},
+		{"QueueSidecarCPULimit", cc.QueueSidecarCPULimit},
+		{"QueueSidecarMemoryLimit", cc.QueueSidecarMemoryLimit},
+
+		{"LogCollectorCPU", cc.LogCollectorCPU},
+		{"LogCollectorMemory", cc.LogCollectorMemory},
+		{"LogCollectorCPULimit", cc.LogCollectorCPULimit},
+		{"LogCollectorMemoryLimit", cc.LogCollectorMemoryLimit},
+	}
+
+	for _, q := range quantities {
+		if q.value == "" {
+			continue
+		}
+		if _, err := resource.ParseQuantity(q.value); err != nil {
+			return fmt.Errorf("ControllerConfig.%s: invalid resource quantity %q: %w", q.field, q.value, err)
+		}
+	}
+	return nil
+}
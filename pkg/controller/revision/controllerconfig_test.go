@@ -0,0 +1,61 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision
+
+import "testing"
+
+func TestValidateControllerConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		cc      *ControllerConfig
+		wantErr bool
+	}{{
+		name:    "all unset is valid",
+		cc:      &ControllerConfig{},
+		wantErr: false,
+	}, {
+		name: "well-formed quantities are valid",
+		cc: &ControllerConfig{
+			QueueSidecarCPU:         "25m",
+			QueueSidecarMemory:      "64Mi",
+			QueueSidecarCPULimit:    "50m",
+			QueueSidecarMemoryLimit: "128Mi",
+			LogCollectorCPU:         "75m",
+			LogCollectorMemory:      "128Mi",
+			LogCollectorCPULimit:    "150m",
+			LogCollectorMemoryLimit: "256Mi",
+		},
+		wantErr: false,
+	}, {
+		name:    "malformed queue CPU request is rejected",
+		cc:      &ControllerConfig{QueueSidecarCPU: "100mm"},
+		wantErr: true,
+	}, {
+		name:    "malformed log collector memory limit is rejected",
+		cc:      &ControllerConfig{LogCollectorMemoryLimit: "not-a-quantity"},
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateControllerConfig(tt.cc)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateControllerConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,270 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision
+
+import (
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	"github.com/knative/serving/pkg/controller"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Names accepted by ControllerConfig.LogCollector.
+const (
+	LogCollectorFluentd   = "fluentd"
+	LogCollectorFluentBit = "fluentbit"
+	LogCollectorVector    = "vector"
+	LogCollectorNone      = "none"
+)
+
+const logCollectorConfigVolumeName = "log-collector-config"
+
+// Container names for the non-Fluentd collectors. Fluentd keeps using the
+// pre-existing fluentdContainerName for backwards compatibility with anyone
+// already depending on that name (e.g. log-based dashboards keyed on it).
+const (
+	fluentBitContainerName = "fluentbit"
+	vectorContainerName    = "vector"
+)
+
+// Exported aliases for the log collector container names, so that
+// pkg/webhook can reserve all of them against user-defined sidecars,
+// whichever backend is configured, without duplicating the values.
+const (
+	FluentdContainerName   = fluentdContainerName
+	FluentBitContainerName = fluentBitContainerName
+	VectorContainerName    = vectorContainerName
+)
+
+// LogCollector knows how to build the sidecar that tails a revision's
+// /var/log and ships it off to whatever backend the operator has chosen.
+// Implementations are selected by ControllerConfig.LogCollector and must not
+// assume Elasticsearch (or any other specific backend) on the other end.
+type LogCollector interface {
+	// MakeContainer returns the sidecar container to add to the pod, or nil
+	// if this collector doesn't need one (e.g. "none").
+	MakeContainer(rev *v1alpha1.Revision, controllerConfig *ControllerConfig) *corev1.Container
+	// MakeVolumes returns any volumes the sidecar's container needs mounted,
+	// beyond the shared varlog volume that MakeElaPodSpec already provides.
+	MakeVolumes(controllerConfig *ControllerConfig) []corev1.Volume
+}
+
+// getLogCollector resolves ControllerConfig.LogCollector to an
+// implementation, defaulting to Fluentd for backwards compatibility.
+func getLogCollector(controllerConfig *ControllerConfig) LogCollector {
+	switch controllerConfig.LogCollector {
+	case LogCollectorFluentBit:
+		return fluentBitLogCollector{}
+	case LogCollectorVector:
+		return vectorLogCollector{}
+	case LogCollectorNone:
+		return noneLogCollector{}
+	case LogCollectorFluentd, "":
+		return fluentdLogCollector{}
+	default:
+		return fluentdLogCollector{}
+	}
+}
+
+type noneLogCollector struct{}
+
+func (noneLogCollector) MakeContainer(*v1alpha1.Revision, *ControllerConfig) *corev1.Container {
+	return nil
+}
+
+func (noneLogCollector) MakeVolumes(*ControllerConfig) []corev1.Volume {
+	return nil
+}
+
+type fluentdLogCollector struct{}
+
+func (fluentdLogCollector) MakeContainer(rev *v1alpha1.Revision, controllerConfig *ControllerConfig) *corev1.Container {
+	configMapName := controllerConfig.LogCollectorConfigMap
+	if configMapName == "" {
+		configMapName = "fluentd-varlog-config"
+	}
+	return &corev1.Container{
+		Name:      fluentdContainerName,
+		Image:     controllerConfig.LogCollectorImage,
+		Resources: logCollectorContainerResources(controllerConfig),
+		Env: []corev1.EnvVar{
+			{
+				Name:  "FLUENTD_ARGS",
+				Value: "--no-supervisor -q",
+			},
+			{
+				Name:  "ELA_CONTAINER_NAME",
+				Value: userContainerName,
+			},
+			{
+				Name:  "ELA_CONFIGURATION",
+				Value: controller.LookupOwningConfigurationName(rev.OwnerReferences),
+			},
+			{
+				Name:  "ELA_REVISION",
+				Value: rev.Name,
+			},
+			{
+				Name:  "ELA_NAMESPACE",
+				Value: rev.Namespace,
+			},
+			{
+				Name: "ELA_POD_NAME",
+				ValueFrom: &corev1.EnvVarSource{
+					FieldRef: &corev1.ObjectFieldSelector{
+						FieldPath: "metadata.name",
+					},
+				},
+			},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      varLogVolumeName,
+				MountPath: "/var/log/revisions",
+			},
+			{
+				Name:      logCollectorConfigVolumeName,
+				MountPath: "/etc/fluent/config.d",
+			},
+		},
+	}
+}
+
+func (fluentdLogCollector) MakeVolumes(controllerConfig *ControllerConfig) []corev1.Volume {
+	configMapName := controllerConfig.LogCollectorConfigMap
+	if configMapName == "" {
+		configMapName = "fluentd-varlog-config"
+	}
+	return []corev1.Volume{configMapLogCollectorVolume(configMapName)}
+}
+
+type fluentBitLogCollector struct{}
+
+func (fluentBitLogCollector) MakeContainer(rev *v1alpha1.Revision, controllerConfig *ControllerConfig) *corev1.Container {
+	return &corev1.Container{
+		Name:      fluentBitContainerName,
+		Image:     controllerConfig.LogCollectorImage,
+		Resources: logCollectorContainerResources(controllerConfig),
+		Env: []corev1.EnvVar{
+			{
+				Name:  "ELA_CONTAINER_NAME",
+				Value: userContainerName,
+			},
+			{
+				Name:  "ELA_CONFIGURATION",
+				Value: controller.LookupOwningConfigurationName(rev.OwnerReferences),
+			},
+			{
+				Name:  "ELA_REVISION",
+				Value: rev.Name,
+			},
+			{
+				Name:  "ELA_NAMESPACE",
+				Value: rev.Namespace,
+			},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      varLogVolumeName,
+				MountPath: "/var/log/revisions",
+			},
+			{
+				Name:      logCollectorConfigVolumeName,
+				MountPath: "/fluent-bit/etc",
+			},
+			{
+				Name:      "fluent-bit-positions",
+				MountPath: "/fluent-bit/tail",
+			},
+		},
+	}
+}
+
+func (fluentBitLogCollector) MakeVolumes(controllerConfig *ControllerConfig) []corev1.Volume {
+	configMapName := controllerConfig.LogCollectorConfigMap
+	if configMapName == "" {
+		configMapName = "fluent-bit-config"
+	}
+	return []corev1.Volume{
+		configMapLogCollectorVolume(configMapName),
+		{
+			Name: "fluent-bit-positions",
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		},
+	}
+}
+
+type vectorLogCollector struct{}
+
+func (vectorLogCollector) MakeContainer(rev *v1alpha1.Revision, controllerConfig *ControllerConfig) *corev1.Container {
+	return &corev1.Container{
+		Name:      vectorContainerName,
+		Image:     controllerConfig.LogCollectorImage,
+		Resources: logCollectorContainerResources(controllerConfig),
+		Env: []corev1.EnvVar{
+			{
+				Name:  "ELA_CONTAINER_NAME",
+				Value: userContainerName,
+			},
+			{
+				Name:  "ELA_CONFIGURATION",
+				Value: controller.LookupOwningConfigurationName(rev.OwnerReferences),
+			},
+			{
+				Name:  "ELA_REVISION",
+				Value: rev.Name,
+			},
+			{
+				Name:  "ELA_NAMESPACE",
+				Value: rev.Namespace,
+			},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      varLogVolumeName,
+				MountPath: "/var/log/revisions",
+			},
+			{
+				Name:      logCollectorConfigVolumeName,
+				MountPath: "/etc/vector",
+			},
+		},
+	}
+}
+
+func (vectorLogCollector) MakeVolumes(controllerConfig *ControllerConfig) []corev1.Volume {
+	configMapName := controllerConfig.LogCollectorConfigMap
+	if configMapName == "" {
+		configMapName = "vector-config"
+	}
+	return []corev1.Volume{configMapLogCollectorVolume(configMapName)}
+}
+
+func configMapLogCollectorVolume(configMapName string) corev1.Volume {
+	return corev1.Volume{
+		Name: logCollectorConfigVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: configMapName,
+				},
+			},
+		},
+	}
+}
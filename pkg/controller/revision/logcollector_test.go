@@ -0,0 +1,66 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision
+
+import (
+	"testing"
+
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+)
+
+func TestGetLogCollector(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   LogCollector
+	}{
+		{name: "defaults to fluentd", config: "", want: fluentdLogCollector{}},
+		{name: "fluentd", config: LogCollectorFluentd, want: fluentdLogCollector{}},
+		{name: "fluentbit", config: LogCollectorFluentBit, want: fluentBitLogCollector{}},
+		{name: "vector", config: LogCollectorVector, want: vectorLogCollector{}},
+		{name: "none", config: LogCollectorNone, want: noneLogCollector{}},
+		{name: "unknown falls back to fluentd", config: "bogus", want: fluentdLogCollector{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := getLogCollector(&ControllerConfig{LogCollector: tt.config})
+			if got != tt.want {
+				t.Errorf("getLogCollector(%q) = %#v, want %#v", tt.config, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogCollectorContainerNamesDontCollide(t *testing.T) {
+	rev := &v1alpha1.Revision{}
+	controllerConfig := &ControllerConfig{LogCollectorImage: "collector:latest"}
+
+	names := map[string]string{
+		"fluentd":   fluentdLogCollector{}.MakeContainer(rev, controllerConfig).Name,
+		"fluentbit": fluentBitLogCollector{}.MakeContainer(rev, controllerConfig).Name,
+		"vector":    vectorLogCollector{}.MakeContainer(rev, controllerConfig).Name,
+	}
+
+	seen := map[string]string{}
+	for backend, name := range names {
+		if other, ok := seen[name]; ok {
+			t.Errorf("backends %q and %q both produce container name %q", backend, other, name)
+		}
+		seen[name] = backend
+	}
+}
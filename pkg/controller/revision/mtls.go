@@ -0,0 +1,96 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision
+
+import (
+	"fmt"
+
+	cmv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	"github.com/knative/serving/pkg/controller"
+
+	corev1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	mtlsCertVolumeName = "mtls-cert"
+	mtlsCertMountPath  = "/var/run/ela/mtls"
+)
+
+// mtlsSecretName returns the name of the Secret that holds the per-revision
+// mTLS certificate, which is also the name given to the Certificate that
+// produces it.
+func mtlsSecretName(rev *v1alpha1.Revision) string {
+	return fmt.Sprintf("%s-mtls", rev.Name)
+}
+
+// MakeElaCertificate builds the cert-manager Certificate that provisions the
+// mTLS keypair shared by the user container and the queue-proxy, when
+// ControllerConfig.EnableMTLS is set. syncCertificate creates and updates it
+// and clearCertificate deletes it; the revision reconciler calls neither.
+func MakeElaCertificate(rev *v1alpha1.Revision, controllerConfig *ControllerConfig) *cmv1.Certificate {
+	return &cmv1.Certificate{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:            mtlsSecretName(rev),
+			Namespace:       rev.Namespace,
+			Labels:          MakeElaResourceLabels(rev),
+			OwnerReferences: []meta_v1.OwnerReference{*controller.NewRevisionControllerRef(rev)},
+		},
+		Spec: cmv1.CertificateSpec{
+			SecretName: mtlsSecretName(rev),
+			CommonName: fmt.Sprintf("%s.%s.svc.cluster.local", rev.Name, rev.Namespace),
+			DNSNames:   []string{fmt.Sprintf("%s.%s.svc.cluster.local", rev.Name, rev.Namespace)},
+			IssuerRef: cmmeta.ObjectReference{
+				Name: controllerConfig.MTLSIssuerName,
+				Kind: controllerConfig.MTLSIssuerKind,
+			},
+		},
+	}
+}
+
+// mtlsCertVolume returns the projected volume mounting the mTLS secret, and
+// true if mTLS is enabled.
+func mtlsCertVolume(rev *v1alpha1.Revision, controllerConfig *ControllerConfig) (corev1.Volume, bool) {
+	if !controllerConfig.EnableMTLS {
+		return corev1.Volume{}, false
+	}
+	return corev1.Volume{
+		Name: mtlsCertVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{
+				Sources: []corev1.VolumeProjection{{
+					Secret: &corev1.SecretProjection{
+						LocalObjectReference: corev1.LocalObjectReference{
+							Name: mtlsSecretName(rev),
+						},
+					},
+				}},
+			},
+		},
+	}, true
+}
+
+func mtlsCertVolumeMount() corev1.VolumeMount {
+	return corev1.VolumeMount{
+		Name:      mtlsCertVolumeName,
+		MountPath: mtlsCertMountPath,
+		ReadOnly:  true,
+	}
+}
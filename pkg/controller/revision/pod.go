@@ -17,9 +17,6 @@ limitations under the License.
 package revision
 
 import (
-	"net"
-	"strings"
-
 	"go.uber.org/zap"
 
 	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
@@ -34,14 +31,24 @@ import (
 )
 
 const (
-	// Each Knative Serving pod gets 1 cpu.
-	userContainerCPU    = "400m"
-	queueContainerCPU   = "25m"
-	fluentdContainerCPU = "75m"
-
-	fluentdConfigMapVolumeName     = "configmap"
-	varLogVolumeName               = "varlog"
-	istioOutboundIPRangeAnnotation = "traffic.sidecar.istio.io/includeOutboundIPRanges"
+	// CPU request given to the user container when the revision doesn't set
+	// one. A CPU request the revision does set is kept as is, even if lower.
+	userContainerMinCPU = "400m"
+
+	queueContainerCPU      = "25m"
+	logCollectorDefaultCPU = "75m"
+
+	varLogVolumeName = "varlog"
+)
+
+// Exported aliases for the container names and port this package adds to
+// every revision's pod, so that pkg/webhook can validate user-defined
+// sidecars against them without duplicating the values.
+const (
+	UserContainerName        = userContainerName
+	QueueContainerName       = queueContainerName
+	AttachProxyContainerName = attachProxyContainerName
+	UserPort                 = userPort
 )
 
 func hasHTTPPath(p *corev1.Probe) bool {
@@ -54,6 +61,19 @@ func hasHTTPPath(p *corev1.Probe) bool {
 	return p.Handler.HTTPGet.Path != ""
 }
 
+// applyUserContainerResourceMinimums returns resources honoring whatever the
+// user requested on rev.Spec.Container, filling in a minimum CPU request
+// when the user didn't specify one so that the container reliably schedules.
+func applyUserContainerResourceMinimums(resources corev1.ResourceRequirements) corev1.ResourceRequirements {
+	if resources.Requests == nil {
+		resources.Requests = corev1.ResourceList{}
+	}
+	if _, ok := resources.Requests[corev1.ResourceCPU]; !ok {
+		resources.Requests[corev1.ResourceCPU] = resource.MustParse(userContainerMinCPU)
+	}
+	return resources
+}
+
 // MakeElaPodSpec creates a pod spec.
 func MakeElaPodSpec(
 	rev *v1alpha1.Revision,
@@ -69,11 +89,7 @@ func MakeElaPodSpec(
 	// Adding or removing an overwritten corev1.Container field here? Don't forget to
 	// update the validations in pkg/webhook.validateContainer.
 	userContainer.Name = userContainerName
-	userContainer.Resources = corev1.ResourceRequirements{
-		Requests: corev1.ResourceList{
-			corev1.ResourceName("cpu"): resource.MustParse(userContainerCPU),
-		},
-	}
+	userContainer.Resources = applyUserContainerResourceMinimums(userContainer.Resources)
 	userContainer.Ports = []corev1.ContainerPort{{
 		Name:          userPortName,
 		ContainerPort: int32(userPort),
@@ -112,109 +128,118 @@ func MakeElaPodSpec(
 		userContainer.ReadinessProbe.Handler.HTTPGet.Port = intstr.FromInt(queue.RequestQueuePort)
 	}
 
+	// TTY/Stream interaction modes put the user container into a mode that
+	// accepts an attach-proxy sidecar multiplexing stdin/stdout/stderr over a
+	// shared Unix socket, so operators can kubectl-style attach to the pod.
+	attachProxy, attachSocketVolume := applyInteractionMode(rev, userContainer, controllerConfig)
+
+	queueContainer := MakeElaQueueContainer(rev, controllerConfig)
+
 	podSpe := &corev1.PodSpec{
-		Containers:         []corev1.Container{*userContainer, *MakeElaQueueContainer(rev, controllerConfig)},
+		Containers:         []corev1.Container{*userContainer, *queueContainer},
 		Volumes:            []corev1.Volume{varLogVolume},
 		ServiceAccountName: rev.Spec.ServiceAccountName,
 	}
 
-	// Add Fluentd sidecar and its config map volume if var log collection is enabled.
-	if controllerConfig.EnableVarLogCollection {
-		fluentdConfigMapVolume := corev1.Volume{
-			Name: fluentdConfigMapVolumeName,
-			VolumeSource: corev1.VolumeSource{
-				ConfigMap: &corev1.ConfigMapVolumeSource{
-					LocalObjectReference: corev1.LocalObjectReference{
-						Name: "fluentd-varlog-config",
-					},
-				},
-			},
-		}
+	if attachProxy != nil {
+		podSpe.Containers = append(podSpe.Containers, *attachProxy)
+		podSpe.Volumes = append(podSpe.Volumes, *attachSocketVolume)
+	}
 
-		fluentdContainer := corev1.Container{
-			Name:  fluentdContainerName,
-			Image: controllerConfig.FluentdSidecarImage,
-			Resources: corev1.ResourceRequirements{
-				Requests: corev1.ResourceList{
-					corev1.ResourceName("cpu"): resource.MustParse(fluentdContainerCPU),
-				},
-			},
-			Env: []corev1.EnvVar{
-				{
-					Name:  "FLUENTD_ARGS",
-					Value: "--no-supervisor -q",
-				},
-				{
-					Name:  "ELA_CONTAINER_NAME",
-					Value: userContainerName,
-				},
-				{
-					Name:  "ELA_CONFIGURATION",
-					Value: controller.LookupOwningConfigurationName(rev.OwnerReferences),
-				},
-				{
-					Name:  "ELA_REVISION",
-					Value: rev.Name,
-				},
-				{
-					Name:  "ELA_NAMESPACE",
-					Value: rev.Namespace,
-				},
-				{
-					Name: "ELA_POD_NAME",
-					ValueFrom: &corev1.EnvVarSource{
-						FieldRef: &corev1.ObjectFieldSelector{
-							FieldPath: "metadata.name",
-						},
-					},
-				},
-			},
-			VolumeMounts: []corev1.VolumeMount{
-				{
-					Name:      varLogVolumeName,
-					MountPath: "/var/log/revisions",
-				},
-				{
-					Name:      fluentdConfigMapVolumeName,
-					MountPath: "/etc/fluent/config.d",
-				},
-			},
+	// When mTLS is enabled, a cert-manager-issued certificate is mounted into
+	// both the user container and the queue-proxy, and the user container's
+	// HTTP readiness and liveness probes use HTTPS instead of plain HTTP. The
+	// PreStop hook keeps its plain HTTP scheme.
+	if mtlsVolume, ok := mtlsCertVolume(rev, controllerConfig); ok {
+		podSpe.Containers[0].VolumeMounts = append(podSpe.Containers[0].VolumeMounts, mtlsCertVolumeMount())
+		podSpe.Containers[1].VolumeMounts = append(podSpe.Containers[1].VolumeMounts, mtlsCertVolumeMount())
+		if hasHTTPPath(podSpe.Containers[0].ReadinessProbe) {
+			podSpe.Containers[0].ReadinessProbe.Handler.HTTPGet.Scheme = corev1.URISchemeHTTPS
 		}
+		if podSpe.Containers[0].LivenessProbe != nil && hasHTTPPath(podSpe.Containers[0].LivenessProbe) {
+			podSpe.Containers[0].LivenessProbe.Handler.HTTPGet.Scheme = corev1.URISchemeHTTPS
+		}
+		podSpe.Volumes = append(podSpe.Volumes, mtlsVolume)
+	}
 
-		podSpe.Containers = append(podSpe.Containers, fluentdContainer)
-		podSpe.Volumes = append(podSpe.Volumes, fluentdConfigMapVolume)
+	// Add any user-defined sidecars (auth proxies, cache warmers, etc.) so
+	// they share the pod with the serving container. Validation that they
+	// don't redeclare the user port or the serving container's name lives in
+	// pkg/webhook.validateSidecars.
+	for _, sidecar := range rev.Spec.Sidecars {
+		podSpe.Containers = append(podSpe.Containers, *sidecar.DeepCopy())
+	}
+
+	// Add the configured log collector's sidecar and volumes if var log
+	// collection is enabled. The collector itself (Fluentd, Fluent Bit,
+	// Vector, or none) is chosen by ControllerConfig.LogCollector.
+	if controllerConfig.EnableVarLogCollection {
+		logCollector := getLogCollector(controllerConfig)
+		if logCollectorContainer := logCollector.MakeContainer(rev, controllerConfig); logCollectorContainer != nil {
+			podSpe.Containers = append(podSpe.Containers, *logCollectorContainer)
+			podSpe.Volumes = append(podSpe.Volumes, logCollector.MakeVolumes(controllerConfig)...)
+		}
 	}
 
 	return podSpe
 }
 
+// logCollectorContainerResources returns the resource requirements for the log
+// collector sidecar, preferring operator-configured values from the
+// ControllerConfig and falling back to the built-in default CPU request when
+// unset.
+func logCollectorContainerResources(controllerConfig *ControllerConfig) corev1.ResourceRequirements {
+	cpu := logCollectorDefaultCPU
+	if controllerConfig.LogCollectorCPU != "" {
+		cpu = controllerConfig.LogCollectorCPU
+	}
+
+	requests := corev1.ResourceList{
+		corev1.ResourceCPU: resource.MustParse(cpu),
+	}
+	if controllerConfig.LogCollectorMemory != "" {
+		requests[corev1.ResourceMemory] = resource.MustParse(controllerConfig.LogCollectorMemory)
+	}
+
+	limits := corev1.ResourceList{}
+	if controllerConfig.LogCollectorCPULimit != "" {
+		limits[corev1.ResourceCPU] = resource.MustParse(controllerConfig.LogCollectorCPULimit)
+	}
+	if controllerConfig.LogCollectorMemoryLimit != "" {
+		limits[corev1.ResourceMemory] = resource.MustParse(controllerConfig.LogCollectorMemoryLimit)
+	}
+
+	resources := corev1.ResourceRequirements{Requests: requests}
+	if len(limits) > 0 {
+		resources.Limits = limits
+	}
+	return resources
+}
+
 // MakeElaDeployment creates a deployment.
 func MakeElaDeployment(logger *zap.SugaredLogger, u *v1alpha1.Revision, namespace string,
-	networkConfig *NetworkConfig) *appsv1.Deployment {
+	networkConfig *NetworkConfig, controllerConfig *ControllerConfig) *appsv1.Deployment {
 	rollingUpdateConfig := appsv1.RollingUpdateDeployment{
 		MaxUnavailable: &elaPodMaxUnavailable,
 		MaxSurge:       &elaPodMaxSurge,
 	}
 
+	serviceMesh := getServiceMesh(controllerConfig)
+
 	podTemplateAnnotations := MakeElaResourceAnnotations(u)
-	podTemplateAnnotations[sidecarIstioInjectAnnotation] = "true"
-
-	// Inject the IP ranges for istio sidecar configuration.
-	// We will inject this value only if all of the following are true:
-	// - the config map contains a non-empty value
-	// - the user doesn't specify this annotation in configuration's pod template
-	// - configured values are valid CIDR notation IP addresses
-	// If these conditions are not met, this value will be left untouched.
-	// * is a special value that is accepted as a valid.
-	// * intercepts calls to all IPs: in cluster as well as outside the cluster.
-	if _, ok := podTemplateAnnotations[istioOutboundIPRangeAnnotation]; !ok {
-		if len(networkConfig.IstioOutboundIPRanges) > 0 {
-			if err := validateOutboundIPRanges(networkConfig.IstioOutboundIPRanges); err != nil {
-				logger.Errorf("Failed to parse IP ranges %v. Not setting the annotation. Error: %v", networkConfig.IstioOutboundIPRanges, err)
-			} else {
-				podTemplateAnnotations[istioOutboundIPRangeAnnotation] = networkConfig.IstioOutboundIPRanges
-			}
-		}
+	serviceMesh.ApplyPodTemplateAnnotations(podTemplateAnnotations, networkConfig, logger)
+
+	podTemplateLabels := MakeElaResourceLabels(u)
+	for k, v := range serviceMesh.PodTemplateLabels() {
+		podTemplateLabels[k] = v
+	}
+
+	// Meshes that need their own iptables-setup init container or extra
+	// volumes (rather than relying on a mutating webhook to inject one)
+	// declare them here.
+	meshPodSpec := corev1.PodSpec{
+		InitContainers: serviceMesh.InitContainers(controllerConfig),
+		Volumes:        serviceMesh.Volumes(controllerConfig),
 	}
 
 	return &appsv1.Deployment{
@@ -233,24 +258,11 @@ func MakeElaDeployment(logger *zap.SugaredLogger, u *v1alpha1.Revision, namespac
 			},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: meta_v1.ObjectMeta{
-					Labels:      MakeElaResourceLabels(u),
+					Labels:      podTemplateLabels,
 					Annotations: podTemplateAnnotations,
 				},
+				Spec: meshPodSpec,
 			},
 		},
 	}
 }
-
-func validateOutboundIPRanges(s string) error {
-	// * is a valid value
-	if s == "*" {
-		return nil
-	}
-	cidrs := strings.Split(s, ",")
-	for _, cidr := range cidrs {
-		if _, _, err := net.ParseCIDR(cidr); err != nil {
-			return err
-		}
-	}
-	return nil
-}
\ No newline at end of file
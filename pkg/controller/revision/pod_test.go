@@ -0,0 +1,80 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestApplyUserContainerResourceMinimums(t *testing.T) {
+	t.Run("fills in the default CPU request when unset", func(t *testing.T) {
+		got := applyUserContainerResourceMinimums(corev1.ResourceRequirements{})
+		want := resource.MustParse(userContainerMinCPU)
+		if cpu := got.Requests[corev1.ResourceCPU]; cpu.Cmp(want) != 0 {
+			t.Errorf("Requests[cpu] = %v, want %v", cpu, want)
+		}
+	})
+
+	t.Run("honors a user-supplied CPU request", func(t *testing.T) {
+		userCPU := resource.MustParse("2")
+		got := applyUserContainerResourceMinimums(corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceCPU: userCPU},
+		})
+		if cpu := got.Requests[corev1.ResourceCPU]; cpu.Cmp(userCPU) != 0 {
+			t.Errorf("Requests[cpu] = %v, want %v", cpu, userCPU)
+		}
+	})
+}
+
+func TestLogCollectorContainerResources(t *testing.T) {
+	t.Run("falls back to the built-in default CPU request when unset", func(t *testing.T) {
+		got := logCollectorContainerResources(&ControllerConfig{})
+		want := resource.MustParse(logCollectorDefaultCPU)
+		if cpu := got.Requests[corev1.ResourceCPU]; cpu.Cmp(want) != 0 {
+			t.Errorf("Requests[cpu] = %v, want %v", cpu, want)
+		}
+		if got.Limits != nil {
+			t.Errorf("Limits = %v, want nil when no limits are configured", got.Limits)
+		}
+	})
+
+	t.Run("honors operator-configured values", func(t *testing.T) {
+		controllerConfig := &ControllerConfig{
+			LogCollectorCPU:         "100m",
+			LogCollectorMemory:      "64Mi",
+			LogCollectorCPULimit:    "200m",
+			LogCollectorMemoryLimit: "128Mi",
+		}
+		got := logCollectorContainerResources(controllerConfig)
+
+		if cpu := got.Requests[corev1.ResourceCPU]; cpu.Cmp(resource.MustParse("100m")) != 0 {
+			t.Errorf("Requests[cpu] = %v, want 100m", cpu)
+		}
+		if mem := got.Requests[corev1.ResourceMemory]; mem.Cmp(resource.MustParse("64Mi")) != 0 {
+			t.Errorf("Requests[memory] = %v, want 64Mi", mem)
+		}
+		if cpu := got.Limits[corev1.ResourceCPU]; cpu.Cmp(resource.MustParse("200m")) != 0 {
+			t.Errorf("Limits[cpu] = %v, want 200m", cpu)
+		}
+		if mem := got.Limits[corev1.ResourceMemory]; mem.Cmp(resource.MustParse("128Mi")) != 0 {
+			t.Errorf("Limits[memory] = %v, want 128Mi", mem)
+		}
+	})
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision
+
+import (
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	"github.com/knative/serving/pkg/controller"
+	"github.com/knative/serving/pkg/queue"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// MakeElaQueueContainer creates the container spec for the queue proxy sidecar,
+// which sits in front of the user container and tracks in-flight requests for
+// autoscaling and graceful shutdown.
+func MakeElaQueueContainer(rev *v1alpha1.Revision, controllerConfig *ControllerConfig) *corev1.Container {
+	return &corev1.Container{
+		Name:      queueContainerName,
+		Image:     controllerConfig.QueueSidecarImage,
+		Resources: queueContainerResources(controllerConfig),
+		Ports: []corev1.ContainerPort{
+			{Name: queue.RequestQueuePortName, ContainerPort: int32(queue.RequestQueuePort)},
+			{Name: queue.RequestQueueAdminPortName, ContainerPort: int32(queue.RequestQueueAdminPort)},
+		},
+		ReadinessProbe: &corev1.Probe{
+			Handler: corev1.Handler{
+				HTTPGet: &corev1.HTTPGetAction{
+					Port: intstr.FromInt(queue.RequestQueuePort),
+				},
+			},
+		},
+		Env: []corev1.EnvVar{
+			{
+				Name:  "ELA_NAMESPACE",
+				Value: rev.Namespace,
+			},
+			{
+				Name:  "ELA_REVISION",
+				Value: rev.Name,
+			},
+			{
+				Name:  "ELA_CONFIGURATION",
+				Value: controller.LookupOwningConfigurationName(rev.OwnerReferences),
+			},
+		},
+	}
+}
+
+// queueContainerResources returns the resource requirements for the queue
+// sidecar, preferring operator-configured values from the ControllerConfig
+// and falling back to the built-in defaults when unset.
+func queueContainerResources(controllerConfig *ControllerConfig) corev1.ResourceRequirements {
+	cpu := queueContainerCPU
+	if controllerConfig.QueueSidecarCPU != "" {
+		cpu = controllerConfig.QueueSidecarCPU
+	}
+
+	requests := corev1.ResourceList{
+		corev1.ResourceCPU: resource.MustParse(cpu),
+	}
+	if controllerConfig.QueueSidecarMemory != "" {
+		requests[corev1.ResourceMemory] = resource.MustParse(controllerConfig.QueueSidecarMemory)
+	}
+
+	limits := corev1.ResourceList{}
+	if controllerConfig.QueueSidecarCPULimit != "" {
+		limits[corev1.ResourceCPU] = resource.MustParse(controllerConfig.QueueSidecarCPULimit)
+	}
+	if controllerConfig.QueueSidecarMemoryLimit != "" {
+		limits[corev1.ResourceMemory] = resource.MustParse(controllerConfig.QueueSidecarMemoryLimit)
+	}
+
+	resources := corev1.ResourceRequirements{Requests: requests}
+	if len(limits) > 0 {
+		resources.Limits = limits
+	}
+	return resources
+}
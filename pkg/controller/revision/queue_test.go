@@ -0,0 +1,60 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestQueueContainerResources(t *testing.T) {
+	t.Run("falls back to the built-in default CPU request when unset", func(t *testing.T) {
+		got := queueContainerResources(&ControllerConfig{})
+		want := resource.MustParse(queueContainerCPU)
+		if cpu := got.Requests[corev1.ResourceCPU]; cpu.Cmp(want) != 0 {
+			t.Errorf("Requests[cpu] = %v, want %v", cpu, want)
+		}
+		if got.Limits != nil {
+			t.Errorf("Limits = %v, want nil when no limits are configured", got.Limits)
+		}
+	})
+
+	t.Run("honors operator-configured values", func(t *testing.T) {
+		controllerConfig := &ControllerConfig{
+			QueueSidecarCPU:         "50m",
+			QueueSidecarMemory:      "32Mi",
+			QueueSidecarCPULimit:    "100m",
+			QueueSidecarMemoryLimit: "64Mi",
+		}
+		got := queueContainerResources(controllerConfig)
+
+		if cpu := got.Requests[corev1.ResourceCPU]; cpu.Cmp(resource.MustParse("50m")) != 0 {
+			t.Errorf("Requests[cpu] = %v, want 50m", cpu)
+		}
+		if mem := got.Requests[corev1.ResourceMemory]; mem.Cmp(resource.MustParse("32Mi")) != 0 {
+			t.Errorf("Requests[memory] = %v, want 32Mi", mem)
+		}
+		if cpu := got.Limits[corev1.ResourceCPU]; cpu.Cmp(resource.MustParse("100m")) != 0 {
+			t.Errorf("Limits[cpu] = %v, want 100m", cpu)
+		}
+		if mem := got.Limits[corev1.ResourceMemory]; mem.Cmp(resource.MustParse("64Mi")) != 0 {
+			t.Errorf("Limits[memory] = %v, want 64Mi", mem)
+		}
+	})
+}
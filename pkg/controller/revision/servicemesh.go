@@ -0,0 +1,181 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision
+
+import (
+	"net"
+	"strings"
+
+	"go.uber.org/zap"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Names accepted by ControllerConfig.ServiceMesh.
+const (
+	ServiceMeshIstio   = "istio"
+	ServiceMeshLinkerd = "linkerd"
+	ServiceMeshNone    = "none"
+)
+
+const (
+	sidecarIstioInjectAnnotation   = "sidecar.istio.io/inject"
+	istioOutboundIPRangeAnnotation = "traffic.sidecar.istio.io/includeOutboundIPRanges"
+
+	linkerdInjectAnnotation      = "linkerd.io/inject"
+	linkerdSkipOutboundPortsAnno = "config.linkerd.io/skip-outbound-ports"
+)
+
+// ServiceMesh owns everything mesh-specific about a revision's pod template:
+// stamping injection annotations/labels, validating its own network config,
+// and (in more involved meshes) declaring extra init containers or volumes.
+// Implementations are selected by ControllerConfig.ServiceMesh.
+type ServiceMesh interface {
+	// ApplyPodTemplateAnnotations stamps the annotations this mesh needs onto
+	// the revision's pod template, validating any mesh-specific network
+	// config (e.g. Istio's outbound IP ranges) along the way. The mesh's
+	// injection annotation is always set; its other annotations are left
+	// untouched if the user already set them.
+	ApplyPodTemplateAnnotations(annotations map[string]string, networkConfig *NetworkConfig, logger *zap.SugaredLogger)
+	// PodTemplateLabels returns labels this mesh needs on the pod template,
+	// beyond the revision's own labels.
+	PodTemplateLabels() map[string]string
+	// InitContainers returns any init containers this mesh needs added to
+	// the pod (e.g. an iptables-setup container), or nil if it doesn't
+	// need one, as with meshes that inject their own via a mutating
+	// webhook instead.
+	InitContainers(controllerConfig *ControllerConfig) []corev1.Container
+	// Volumes returns any volumes this mesh's init containers or sidecar
+	// need mounted, beyond what MakeElaPodSpec already provides.
+	Volumes(controllerConfig *ControllerConfig) []corev1.Volume
+}
+
+// getServiceMesh resolves ControllerConfig.ServiceMesh to an implementation,
+// defaulting to Istio for backwards compatibility.
+func getServiceMesh(controllerConfig *ControllerConfig) ServiceMesh {
+	switch controllerConfig.ServiceMesh {
+	case ServiceMeshLinkerd:
+		return linkerdServiceMesh{}
+	case ServiceMeshNone:
+		return noneServiceMesh{}
+	case ServiceMeshIstio, "":
+		return istioServiceMesh{}
+	default:
+		return istioServiceMesh{}
+	}
+}
+
+type noneServiceMesh struct{}
+
+func (noneServiceMesh) ApplyPodTemplateAnnotations(map[string]string, *NetworkConfig, *zap.SugaredLogger) {
+}
+
+func (noneServiceMesh) PodTemplateLabels() map[string]string {
+	return nil
+}
+
+func (noneServiceMesh) InitContainers(*ControllerConfig) []corev1.Container {
+	return nil
+}
+
+func (noneServiceMesh) Volumes(*ControllerConfig) []corev1.Volume {
+	return nil
+}
+
+type istioServiceMesh struct{}
+
+func (istioServiceMesh) ApplyPodTemplateAnnotations(annotations map[string]string, networkConfig *NetworkConfig, logger *zap.SugaredLogger) {
+	annotations[sidecarIstioInjectAnnotation] = "true"
+
+	// Inject the IP ranges for istio sidecar configuration.
+	// We will inject this value only if all of the following are true:
+	// - the config map contains a non-empty value
+	// - the user doesn't specify this annotation in configuration's pod template
+	// - configured values are valid CIDR notation IP addresses
+	// If these conditions are not met, this value will be left untouched.
+	// * is a special value that is accepted as a valid.
+	// * intercepts calls to all IPs: in cluster as well as outside the cluster.
+	if _, ok := annotations[istioOutboundIPRangeAnnotation]; !ok {
+		if len(networkConfig.IstioOutboundIPRanges) > 0 {
+			if err := validateOutboundIPRanges(networkConfig.IstioOutboundIPRanges); err != nil {
+				logger.Errorf("Failed to parse IP ranges %v. Not setting the annotation. Error: %v", networkConfig.IstioOutboundIPRanges, err)
+			} else {
+				annotations[istioOutboundIPRangeAnnotation] = networkConfig.IstioOutboundIPRanges
+			}
+		}
+	}
+}
+
+func (istioServiceMesh) PodTemplateLabels() map[string]string {
+	return nil
+}
+
+func (istioServiceMesh) InitContainers(*ControllerConfig) []corev1.Container {
+	// Istio's sidecar injector webhook adds its own init container, so
+	// there's nothing for us to add here.
+	return nil
+}
+
+func (istioServiceMesh) Volumes(*ControllerConfig) []corev1.Volume {
+	return nil
+}
+
+func validateOutboundIPRanges(s string) error {
+	// * is a valid value
+	if s == "*" {
+		return nil
+	}
+	cidrs := strings.Split(s, ",")
+	for _, cidr := range cidrs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type linkerdServiceMesh struct{}
+
+func (linkerdServiceMesh) ApplyPodTemplateAnnotations(annotations map[string]string, networkConfig *NetworkConfig, logger *zap.SugaredLogger) {
+	annotations[linkerdInjectAnnotation] = "enabled"
+
+	// Tell Linkerd's proxy to pass through the ports it shouldn't
+	// intercept (e.g. the queue-proxy's own outbound calls), the same way
+	// Istio's outbound IP ranges are user-configurable rather than
+	// hardcoded. Left untouched if the user already set it.
+	if _, ok := annotations[linkerdSkipOutboundPortsAnno]; !ok {
+		if networkConfig.LinkerdSkipOutboundPorts != "" {
+			annotations[linkerdSkipOutboundPortsAnno] = networkConfig.LinkerdSkipOutboundPorts
+		}
+	}
+}
+
+func (linkerdServiceMesh) PodTemplateLabels() map[string]string {
+	// Linkerd's injector only reads linkerdInjectAnnotation, already set in
+	// ApplyPodTemplateAnnotations; no labels needed.
+	return nil
+}
+
+func (linkerdServiceMesh) InitContainers(*ControllerConfig) []corev1.Container {
+	// Linkerd's proxy-injector webhook adds its own init container, so
+	// there's nothing for us to add here.
+	return nil
+}
+
+func (linkerdServiceMesh) Volumes(*ControllerConfig) []corev1.Volume {
+	return nil
+}
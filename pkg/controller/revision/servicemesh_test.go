@@ -0,0 +1,79 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestGetServiceMesh(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   ServiceMesh
+	}{
+		{name: "defaults to istio", config: "", want: istioServiceMesh{}},
+		{name: "istio", config: ServiceMeshIstio, want: istioServiceMesh{}},
+		{name: "linkerd", config: ServiceMeshLinkerd, want: linkerdServiceMesh{}},
+		{name: "none", config: ServiceMeshNone, want: noneServiceMesh{}},
+		{name: "unknown falls back to istio", config: "bogus", want: istioServiceMesh{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := getServiceMesh(&ControllerConfig{ServiceMesh: tt.config})
+			if got != tt.want {
+				t.Errorf("getServiceMesh(%q) = %#v, want %#v", tt.config, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinkerdPodTemplateLabelsIsEmpty(t *testing.T) {
+	if got := (linkerdServiceMesh{}).PodTemplateLabels(); got != nil {
+		t.Errorf("PodTemplateLabels() = %v, want nil now that injection is annotation-only", got)
+	}
+}
+
+func TestLinkerdApplyPodTemplateAnnotations(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+
+	annotations := map[string]string{}
+	networkConfig := &NetworkConfig{LinkerdSkipOutboundPorts: "8012,8013"}
+	linkerdServiceMesh{}.ApplyPodTemplateAnnotations(annotations, networkConfig, logger)
+
+	if got := annotations[linkerdInjectAnnotation]; got != "enabled" {
+		t.Errorf("linkerdInjectAnnotation = %q, want %q", got, "enabled")
+	}
+	if got := annotations[linkerdSkipOutboundPortsAnno]; got != "8012,8013" {
+		t.Errorf("linkerdSkipOutboundPortsAnno = %q, want %q", got, "8012,8013")
+	}
+}
+
+func TestLinkerdApplyPodTemplateAnnotationsLeavesUserValue(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+
+	annotations := map[string]string{linkerdSkipOutboundPortsAnno: "9090"}
+	networkConfig := &NetworkConfig{LinkerdSkipOutboundPorts: "8012,8013"}
+	linkerdServiceMesh{}.ApplyPodTemplateAnnotations(annotations, networkConfig, logger)
+
+	if got := annotations[linkerdSkipOutboundPortsAnno]; got != "9090" {
+		t.Errorf("linkerdSkipOutboundPortsAnno = %q, want user-set value %q", got, "9090")
+	}
+}
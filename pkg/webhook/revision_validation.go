@@ -0,0 +1,81 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"fmt"
+
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	"github.com/knative/serving/pkg/controller/revision"
+)
+
+// reservedSidecarNames are the container names the revision controller
+// itself adds to the pod (see pkg/controller/revision.MakeElaPodSpec). A
+// user-defined sidecar can't reuse any of them without clobbering the
+// container it's named after.
+var reservedSidecarNames = []string{
+	revision.UserContainerName,
+	revision.QueueContainerName,
+	revision.AttachProxyContainerName,
+	revision.FluentdContainerName,
+	revision.FluentBitContainerName,
+	revision.VectorContainerName,
+}
+
+// validateSpec runs the semantic validations on a Revision that the
+// generated schema can't express: the interaction-mode constraints and
+// validateSidecars. It does not run validateContainer.
+func validateSpec(rev *v1alpha1.Revision) error {
+	if err := revision.ValidateInteractionMode(rev); err != nil {
+		return err
+	}
+	return validateSidecars(rev)
+}
+
+// validateSidecars checks the user-defined sidecars on rev.Spec.Sidecars
+// against the containers the revision controller adds to the same pod: they
+// can't redeclare a reserved container name, redeclare the user container's
+// port, or clobber the serving container's PreStop hook.
+func validateSidecars(rev *v1alpha1.Revision) error {
+	if len(rev.Spec.Sidecars) == 0 {
+		return nil
+	}
+
+	names := map[string]bool{}
+	for _, reserved := range reservedSidecarNames {
+		names[reserved] = true
+	}
+
+	for _, sidecar := range rev.Spec.Sidecars {
+		if names[sidecar.Name] {
+			return fmt.Errorf("sidecar container name %q collides with a container the revision controller adds to the pod", sidecar.Name)
+		}
+		names[sidecar.Name] = true
+
+		for _, port := range sidecar.Ports {
+			if port.ContainerPort == revision.UserPort {
+				return fmt.Errorf("sidecar %q may not redeclare the user container's port %d", sidecar.Name, revision.UserPort)
+			}
+		}
+
+		if sidecar.Lifecycle != nil && sidecar.Lifecycle.PreStop != nil {
+			return fmt.Errorf("sidecar %q may not set its own PreStop hook; that's reserved for the serving container's shutdown handling", sidecar.Name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"testing"
+
+	"github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	"github.com/knative/serving/pkg/controller/revision"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestValidateSidecars(t *testing.T) {
+	tests := []struct {
+		name     string
+		sidecars []corev1.Container
+		wantErr  bool
+	}{{
+		name:     "no sidecars",
+		sidecars: nil,
+		wantErr:  false,
+	}, {
+		name:     "well-formed sidecar",
+		sidecars: []corev1.Container{{Name: "auth-proxy"}},
+		wantErr:  false,
+	}, {
+		name:     "collides with user container name",
+		sidecars: []corev1.Container{{Name: revision.UserContainerName}},
+		wantErr:  true,
+	}, {
+		name:     "collides with queue proxy name",
+		sidecars: []corev1.Container{{Name: revision.QueueContainerName}},
+		wantErr:  true,
+	}, {
+		name:     "collides with the fluentbit log collector name",
+		sidecars: []corev1.Container{{Name: revision.FluentBitContainerName}},
+		wantErr:  true,
+	}, {
+		name: "redeclares the user port",
+		sidecars: []corev1.Container{{
+			Name:  "auth-proxy",
+			Ports: []corev1.ContainerPort{{ContainerPort: revision.UserPort}},
+		}},
+		wantErr: true,
+	}, {
+		name: "sets its own PreStop hook",
+		sidecars: []corev1.Container{{
+			Name: "auth-proxy",
+			Lifecycle: &corev1.Lifecycle{
+				PreStop: &corev1.Handler{Exec: &corev1.ExecAction{Command: []string{"true"}}},
+			},
+		}},
+		wantErr: true,
+	}, {
+		name:     "two sidecars with the same name",
+		sidecars: []corev1.Container{{Name: "auth-proxy"}, {Name: "auth-proxy"}},
+		wantErr:  true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rev := &v1alpha1.Revision{Spec: v1alpha1.RevisionSpec{Sidecars: tt.sidecars}}
+			err := validateSidecars(rev)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateSidecars() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}